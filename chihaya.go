@@ -8,10 +8,14 @@
 package chihaya
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 
@@ -19,6 +23,7 @@ import (
 
 	"github.com/psaab/chihaya/api"
 	"github.com/psaab/chihaya/config"
+	"github.com/psaab/chihaya/grpcapi"
 	"github.com/psaab/chihaya/http"
 	"github.com/psaab/chihaya/stats"
 	"github.com/psaab/chihaya/tracker"
@@ -40,6 +45,153 @@ type server interface {
 	Stop()
 }
 
+// listenFDsEnv names the environment variable used to tell a freshly
+// exec'd child process which inherited file descriptors belong to which
+// service, e.g. "http:3,udp:4".
+const listenFDsEnv = "CHIHAYA_LISTEN_FDS"
+
+// restartableServer is implemented by servers whose listening socket can be
+// handed across a graceful restart.
+type restartableServer interface {
+	server
+	ListenerFile() (*os.File, error)
+}
+
+// inheritedListenerFile looks up the inherited file descriptor for the
+// named service (as set by a parent process via listenFDsEnv), if any.
+func inheritedListenerFile(name string) (*os.File, bool) {
+	spec := os.Getenv(listenFDsEnv)
+	if spec == "" {
+		return nil, false
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] != name {
+			continue
+		}
+
+		fd, err := strconv.Atoi(parts[1])
+		if err != nil {
+			glog.Errorf("Invalid fd in %s: %s", listenFDsEnv, entry)
+			return nil, false
+		}
+
+		return os.NewFile(uintptr(fd), name), true
+	}
+
+	return nil, false
+}
+
+// fdOrder is the positional order in which listener sockets are expected
+// to be passed by systemd/launchd socket activation when LISTEN_FDNAMES
+// isn't set, matching the order Boot creates servers in.
+var fdOrder = []string{"http", "udp", "grpc"}
+
+// systemdListenerFile returns the inherited file descriptor for the named
+// service under systemd/launchd socket activation. It matches by the
+// LISTEN_FDNAMES hint when present, falling back to fdOrder otherwise, so
+// operators can run chihaya as a non-root socket-activated unit bound to
+// privileged ports.
+func systemdListenerFile(name string) (*os.File, bool) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, false
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, false
+	}
+
+	names := fdOrder
+	if hint := os.Getenv("LISTEN_FDNAMES"); hint != "" {
+		names = strings.Split(hint, ":")
+	}
+
+	for i, fdName := range names {
+		if fdName == name && i < n {
+			return os.NewFile(uintptr(3+i), name), true
+		}
+	}
+
+	return nil, false
+}
+
+// resolveInheritedListener returns a file descriptor to adopt for the named
+// service instead of binding a fresh socket, checking our own graceful
+// restart handoff first and falling back to systemd/launchd socket
+// activation.
+func resolveInheritedListener(name string) (*os.File, bool) {
+	if f, ok := inheritedListenerFile(name); ok {
+		return f, true
+	}
+	return systemdListenerFile(name)
+}
+
+// spawnReplacement forks/execs a new copy of the running binary, handing
+// over the already-bound listener sockets (and the UDP connection ID HMAC
+// key) so the replacement can start accepting traffic immediately while
+// this process drains its in-flight announces/scrapes and exits.
+func spawnReplacement(named map[string]server) error {
+	var fdNames []string
+	var extraFiles []*os.File
+
+	// The REST admin API (named["api"]) has no listener handoff support,
+	// so a graceful restart would leave the child racing the still-live
+	// parent for that port and crashing on "address already in use".
+	// Refuse the restart instead of taking the tracker down.
+	if _, ok := named["api"]; ok {
+		if _, ok := named["api"].(restartableServer); !ok {
+			return errors.New("graceful restart is not supported with api.addr configured")
+		}
+	}
+
+	for _, name := range []string{"http", "udp", "grpc"} {
+		srv, ok := named[name]
+		if !ok {
+			continue
+		}
+
+		rs, ok := srv.(restartableServer)
+		if !ok {
+			continue
+		}
+
+		f, err := rs.ListenerFile()
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		defer f.Close()
+
+		extraFiles = append(extraFiles, f)
+		fdNames = append(fdNames, fmt.Sprintf("%s:%d", name, 2+len(extraFiles)))
+	}
+
+	if len(extraFiles) == 0 {
+		return errors.New("no restartable listeners to hand off")
+	}
+
+	env := append(os.Environ(), listenFDsEnv+"="+strings.Join(fdNames, ","))
+
+	if udpSrv, ok := named["udp"].(interface{ ConnectionIDIV() []byte }); ok {
+		env = append(env, udp.ConnIDIVEnv+"="+fmt.Sprintf("%x", udpSrv.ConnectionIDIV()))
+	}
+
+	files := append([]*os.File{os.Stdin, os.Stdout, os.Stderr}, extraFiles...)
+
+	proc, err := os.StartProcess(os.Args[0], os.Args, &os.ProcAttr{
+		Env:   env,
+		Files: files,
+	})
+	if err != nil {
+		return err
+	}
+
+	glog.Infof("Spawned replacement process %d", proc.Pid)
+	return nil
+}
+
 // Boot starts Chihaya. By exporting this function, anyone can import their own
 // custom drivers into their own package main and then call chihaya.Boot.
 func Boot() {
@@ -72,17 +224,58 @@ func Boot() {
 	}
 
 	var servers []server
+	named := make(map[string]server)
 
 	if cfg.APIConfig.ListenAddr != "" {
-		servers = append(servers, api.NewServer(cfg, tkr))
+		srv := api.NewServer(cfg, tkr)
+		servers = append(servers, srv)
+		named["api"] = srv
 	}
 
 	if cfg.HTTPConfig.ListenAddr != "" {
-		servers = append(servers, http.NewServer(cfg, tkr))
+		srv := http.NewServer(cfg, tkr)
+		if f, ok := resolveInheritedListener("http"); ok {
+			srv.SetInheritedListener(f)
+		}
+		servers = append(servers, srv)
+		named["http"] = srv
 	}
 
 	if cfg.UDPConfig.ListenAddr != "" {
-		servers = append(servers, udp.NewServer(cfg, tkr))
+		srv := udp.NewServer(cfg, tkr)
+		if f, ok := resolveInheritedListener("udp"); ok {
+			srv.SetInheritedListener(f)
+		}
+		servers = append(servers, srv)
+		named["udp"] = srv
+	}
+
+	if cfg.GRPCConfig.ListenAddr != "" {
+		// A dedicated gRPC port was requested, so run it as its own server
+		// rather than multiplexing it onto the HTTP listener.
+		srv := grpcapi.NewServer(cfg, tkr)
+		if f, ok := resolveInheritedListener("grpc"); ok {
+			srv.SetInheritedListener(f)
+		}
+		servers = append(servers, srv)
+		named["grpc"] = srv
+	} else if cfg.GRPCConfig.MountOnHTTP {
+		// Operators must opt in to exposing the gRPC admin API (full
+		// tracker admin control, including user passkeys) on the
+		// tracker's public HTTP listener; it is never mounted by default.
+		//
+		// The cmux match used to pick out gRPC traffic only recognizes
+		// cleartext h2 HEADERS frames, so it can't see inside a TLS
+		// ClientHello; mounting on a TLS-enabled HTTP listener would
+		// silently never match and leave the admin API unreachable.
+		// Refuse to start rather than ship a gRPC API that looks
+		// configured but never receives a request.
+		if cfg.HTTPConfig.ACME != nil || (cfg.HTTPConfig.TLSCertPath != "" && cfg.HTTPConfig.TLSKeyPath != "") {
+			glog.Fatal("grpc.mount_on_http is not supported on a TLS-enabled HTTP listener; use grpc.addr for a dedicated gRPC port instead")
+		}
+		if httpSrv, ok := named["http"].(*http.Server); ok {
+			httpSrv.SetGRPCServer(grpcapi.NewServer(cfg, tkr).GRPCServer())
+		}
 	}
 
 	var wg sync.WaitGroup
@@ -100,14 +293,31 @@ func Boot() {
 	shutdown := make(chan os.Signal)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
+	restart := make(chan os.Signal, 1)
+	signal.Notify(restart, syscall.SIGUSR2, syscall.SIGHUP)
+
 	go func() {
 		wg.Wait()
 		signal.Stop(shutdown)
+		signal.Stop(restart)
 		close(shutdown)
 	}()
 
-	<-shutdown
-	glog.Info("Shutting down...")
+waitForSignal:
+	for {
+		select {
+		case <-shutdown:
+			glog.Info("Shutting down...")
+			break waitForSignal
+		case <-restart:
+			glog.Info("Restarting gracefully...")
+			if err := spawnReplacement(named); err != nil {
+				glog.Errorf("Failed to spawn replacement process, still serving: %s", err)
+				continue
+			}
+			break waitForSignal
+		}
+	}
 
 	for _, srv := range servers {
 		srv.Stop()
@@ -0,0 +1,132 @@
+// Copyright 2015 The Chihaya Authors. All rights reserved.
+// Use of this source code is governed by the BSD 2-Clause license,
+// which can be found in the LICENSE file.
+
+// Package config implements the configuration for a Chihaya tracker.
+package config
+
+import (
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Duration wraps a time.Duration so it can be unmarshaled from a
+// human-readable string such as "15s" in the YAML config file.
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	d.Duration = dur
+	return nil
+}
+
+// ACMEConfig configures automatic TLS certificate provisioning via ACME
+// (e.g. Let's Encrypt), as an alternative to TLSCertPath/TLSKeyPath.
+type ACMEConfig struct {
+	// Hosts is the whitelist of hostnames the tracker is willing to
+	// request/serve certificates for.
+	Hosts []string `yaml:"hosts"`
+	// Email is the contact address given to the ACME directory.
+	Email string `yaml:"email"`
+	// CacheDir is where issued certificates and account keys are cached
+	// between restarts.
+	CacheDir string `yaml:"cache_dir"`
+	// DirectoryURL overrides the ACME directory endpoint, e.g. to point at
+	// a staging environment. Defaults to Let's Encrypt's production
+	// directory when empty.
+	DirectoryURL string `yaml:"directory_url"`
+}
+
+// APIConfig is the configuration for the tracker's REST admin API.
+type APIConfig struct {
+	ListenAddr string `yaml:"addr"`
+}
+
+// HTTPConfig is the configuration for the HTTP announce/scrape endpoints.
+type HTTPConfig struct {
+	ListenAddr  string      `yaml:"addr"`
+	ListenLimit int         `yaml:"listen_limit"`
+	TLSCertPath string      `yaml:"tls_cert_path"`
+	TLSKeyPath  string      `yaml:"tls_key_path"`
+	ACME        *ACMEConfig `yaml:"acme"`
+	// DisableHTTP2 turns off HTTP/2 (both ALPN-negotiated h2 over TLS and
+	// cleartext h2c) for operators who hit interop issues with niche
+	// clients.
+	DisableHTTP2   bool     `yaml:"disable_http2"`
+	ReadTimeout    Duration `yaml:"read_timeout"`
+	WriteTimeout   Duration `yaml:"write_timeout"`
+	RequestTimeout Duration `yaml:"request_timeout"`
+}
+
+// UDPConfig is the configuration for the UDP announce/scrape endpoint.
+type UDPConfig struct {
+	ListenAddr     string `yaml:"addr"`
+	ReadBufferSize int    `yaml:"read_buffer_size"`
+}
+
+// StatsConfig is the configuration for the tracker's runtime statistics.
+type StatsConfig struct {
+	ReportInterval Duration `yaml:"report_interval"`
+}
+
+// GRPCConfig is the configuration for the gRPC tracker admin API.
+type GRPCConfig struct {
+	// ListenAddr runs the gRPC admin API on its own dedicated port. Leave
+	// empty to keep it off, or combine with MountOnHTTP to serve it
+	// alongside announce/scrape traffic instead.
+	ListenAddr string `yaml:"addr"`
+	// MountOnHTTP multiplexes the gRPC admin API onto the HTTP listener
+	// via cmux when ListenAddr is empty. This exposes full tracker admin
+	// control (including user passkeys), so it must be explicitly enabled
+	// rather than being on by default. Not supported when the HTTP
+	// listener has TLS (ACME or TLSCertPath/TLSKeyPath) configured, since
+	// cmux can't match gRPC's content-type header inside a TLS
+	// ClientHello; use a dedicated ListenAddr in that case instead.
+	MountOnHTTP bool `yaml:"mount_on_http"`
+}
+
+// Config is the configuration used to boot a Chihaya tracker.
+type Config struct {
+	APIConfig   `yaml:"api"`
+	HTTPConfig  `yaml:"http"`
+	UDPConfig   `yaml:"udp"`
+	StatsConfig `yaml:"stats"`
+	GRPCConfig  `yaml:"grpc"`
+}
+
+// DefaultConfig is the configuration used when no config file is given.
+var DefaultConfig = Config{}
+
+// Open parses the configuration file at the given path. An empty path
+// returns DefaultConfig.
+func Open(path string) (*Config, error) {
+	if path == "" {
+		return &DefaultConfig, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(contents, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
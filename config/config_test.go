@@ -0,0 +1,95 @@
+// Copyright 2015 The Chihaya Authors. All rights reserved.
+// Use of this source code is governed by the BSD 2-Clause license,
+// which can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestOpenEmptyPathReturnsDefaultConfig(t *testing.T) {
+	cfg, err := Open("")
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	if cfg != &DefaultConfig {
+		t.Error("Open(\"\") did not return &DefaultConfig")
+	}
+}
+
+func TestOpenMissingFile(t *testing.T) {
+	if _, err := Open("/nonexistent/chihaya.yaml"); err == nil {
+		t.Error("expected an error opening a nonexistent config file")
+	}
+}
+
+func TestOpenParsesYAML(t *testing.T) {
+	const yaml = `
+http:
+  addr: "127.0.0.1:6881"
+  read_timeout: "15s"
+grpc:
+  mount_on_http: true
+`
+	f, err := ioutil.TempFile("", "chihaya-config-test")
+	if err != nil {
+		t.Fatalf("TempFile: %s", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(yaml); err != nil {
+		t.Fatalf("WriteString: %s", err)
+	}
+	f.Close()
+
+	cfg, err := Open(f.Name())
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+
+	if got, want := cfg.HTTPConfig.ListenAddr, "127.0.0.1:6881"; got != want {
+		t.Errorf("HTTPConfig.ListenAddr = %q, want %q", got, want)
+	}
+	if got, want := cfg.HTTPConfig.ReadTimeout.Duration, 15*time.Second; got != want {
+		t.Errorf("HTTPConfig.ReadTimeout = %s, want %s", got, want)
+	}
+	if !cfg.GRPCConfig.MountOnHTTP {
+		t.Error("GRPCConfig.MountOnHTTP = false, want true")
+	}
+}
+
+func TestDurationUnmarshalYAML(t *testing.T) {
+	cases := []struct {
+		yaml    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{yaml: `"15s"`, want: 15 * time.Second},
+		{yaml: `"1h30m"`, want: 90 * time.Minute},
+		{yaml: `"not-a-duration"`, wantErr: true},
+	}
+
+	for _, c := range cases {
+		var d Duration
+		err := yaml.Unmarshal([]byte(c.yaml), &d)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("UnmarshalYAML(%q): expected an error", c.yaml)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("UnmarshalYAML(%q): %s", c.yaml, err)
+			continue
+		}
+		if d.Duration != c.want {
+			t.Errorf("UnmarshalYAML(%q) = %s, want %s", c.yaml, d.Duration, c.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2015 The Chihaya Authors. All rights reserved.
+// Use of this source code is governed by the BSD 2-Clause license,
+// which can be found in the LICENSE file.
+
+// Package grpcapi exposes the tracker admin operations also served by the
+// REST api package (get/put user, get/put torrent, purge, stats) as a
+// protobuf/gRPC service, so operators get strongly-typed clients and
+// streaming stats without burning an extra port or TLS cert.
+package grpcapi
+
+import (
+	"errors"
+	"net"
+	"os"
+	"time"
+
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+
+	"github.com/golang/glog"
+
+	"github.com/psaab/chihaya/config"
+	"github.com/psaab/chihaya/stats"
+	"github.com/psaab/chihaya/tracker"
+)
+
+// Server implements TrackerAdminServer, backed directly by the shared
+// tracker. It can either be served on its own listener or mounted on a
+// shared cmux listener via GRPCServer.
+type Server struct {
+	config  *config.Config
+	tracker *tracker.Tracker
+	grpc    *grpc.Server
+
+	listener      net.Listener
+	inheritedFile *os.File
+}
+
+// NewServer returns a new gRPC admin server for a given configuration and
+// tracker.
+func NewServer(cfg *config.Config, tkr *tracker.Tracker) *Server {
+	s := &Server{
+		config:  cfg,
+		tracker: tkr,
+		grpc:    grpc.NewServer(),
+	}
+	RegisterTrackerAdminServer(s.grpc, s)
+	return s
+}
+
+// GRPCServer returns the underlying *grpc.Server so it can be mounted on a
+// cmux sub-listener alongside HTTP/HTTPS announce traffic.
+func (s *Server) GRPCServer() *grpc.Server {
+	return s.grpc
+}
+
+// SetInheritedListener configures the server to adopt an already-bound TCP
+// socket (handed down across a graceful restart) instead of calling
+// net.Listen.
+func (s *Server) SetInheritedListener(f *os.File) {
+	s.inheritedFile = f
+}
+
+// ListenerFile returns a duplicated file descriptor for the server's
+// listening socket, suitable for passing to a child process across a
+// graceful restart.
+func (s *Server) ListenerFile() (*os.File, error) {
+	tl, ok := s.listener.(*net.TCPListener)
+	if !ok {
+		return nil, errors.New("listener does not support file handoff")
+	}
+	return tl.File()
+}
+
+// Serve runs a standalone gRPC server on cfg.GRPCConfig.ListenAddr,
+// blocking until the server has shut down. If ListenAddr is empty, this
+// method is never called: chihaya.Boot mounts the gRPC service on the HTTP
+// listener only when GRPCConfig.MountOnHTTP is set, and otherwise does not
+// start it at all.
+func (s *Server) Serve() {
+	glog.V(0).Info("Starting gRPC admin API on ", s.config.GRPCConfig.ListenAddr)
+
+	var l net.Listener
+	var err error
+
+	if s.inheritedFile != nil {
+		l, err = net.FileListener(s.inheritedFile)
+		if err != nil {
+			panic(err)
+		}
+		s.inheritedFile.Close()
+	} else {
+		l, err = net.Listen("tcp", s.config.GRPCConfig.ListenAddr)
+		if err != nil {
+			panic(err)
+		}
+	}
+	s.listener = l
+
+	if err := s.grpc.Serve(l); err != nil {
+		glog.Errorf("Failed to run gRPC admin API: %s", err.Error())
+	} else {
+		glog.Info("gRPC admin API shut down cleanly")
+	}
+}
+
+// Stop cleanly shuts down the server, allowing in-flight RPCs to finish.
+func (s *Server) Stop() {
+	s.grpc.GracefulStop()
+}
+
+func (s *Server) GetUser(ctx context.Context, req *GetUserRequest) (*User, error) {
+	u, err := s.tracker.GetUser(req.Passkey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &User{
+		Id:             u.ID,
+		Passkey:        u.Passkey,
+		UpMultiplier:   int64(u.UpMultiplier),
+		DownMultiplier: int64(u.DownMultiplier),
+	}, nil
+}
+
+func (s *Server) PutUser(ctx context.Context, req *PutUserRequest) (*Empty, error) {
+	u := &tracker.User{
+		ID:             req.User.Id,
+		Passkey:        req.User.Passkey,
+		UpMultiplier:   float64(req.User.UpMultiplier),
+		DownMultiplier: float64(req.User.DownMultiplier),
+	}
+
+	if err := s.tracker.PutUser(u); err != nil {
+		return nil, err
+	}
+	return &Empty{}, nil
+}
+
+func (s *Server) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*Empty, error) {
+	if err := s.tracker.RemoveUser(req.Passkey); err != nil {
+		return nil, err
+	}
+	return &Empty{}, nil
+}
+
+func (s *Server) GetTorrent(ctx context.Context, req *GetTorrentRequest) (*Torrent, error) {
+	t, err := s.tracker.GetTorrent(req.InfoHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Torrent{
+		InfoHash: t.InfoHash,
+		Active:   t.Active,
+		Seeders:  int64(len(t.Seeders)),
+		Leechers: int64(len(t.Leechers)),
+		Snatches: int64(t.Snatches),
+	}, nil
+}
+
+func (s *Server) PutTorrent(ctx context.Context, req *PutTorrentRequest) (*Empty, error) {
+	t := &tracker.Torrent{
+		InfoHash: req.Torrent.InfoHash,
+		Active:   req.Torrent.Active,
+	}
+
+	if err := s.tracker.PutTorrent(t); err != nil {
+		return nil, err
+	}
+	return &Empty{}, nil
+}
+
+func (s *Server) DeleteTorrent(ctx context.Context, req *DeleteTorrentRequest) (*Empty, error) {
+	if err := s.tracker.RemoveTorrent(req.InfoHash); err != nil {
+		return nil, err
+	}
+	return &Empty{}, nil
+}
+
+func (s *Server) Purge(ctx context.Context, req *PurgeRequest) (*Empty, error) {
+	if err := s.tracker.Purge(req.InfoHash, req.Passkey); err != nil {
+		return nil, err
+	}
+	return &Empty{}, nil
+}
+
+// Stats streams a snapshot of the tracker's global stats on the requested
+// interval, or a single snapshot if IntervalSeconds is zero.
+func (s *Server) Stats(req *StatsRequest, stream TrackerAdmin_StatsServer) error {
+	send := func() error {
+		st := stats.DefaultStats.Snapshot()
+		return stream.Send(&StatsSnapshot{
+			Announces:       st.Announces,
+			Scrapes:         st.Scrapes,
+			ErroredRequests: st.ErroredRequests,
+			OpenConnections: st.OpenConnections,
+		})
+	}
+
+	if req.IntervalSeconds <= 0 {
+		return send()
+	}
+
+	ticker := time.NewTicker(time.Duration(req.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := send(); err != nil {
+			return err
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -9,9 +9,11 @@ package http
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -20,10 +22,15 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/soheilhy/cmux"
 	"github.com/tylerb/graceful"
-
-	"github.com/chihaya/chihaya/config"
-	"github.com/chihaya/chihaya/stats"
-	"github.com/chihaya/chihaya/tracker"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+
+	"github.com/psaab/chihaya/config"
+	"github.com/psaab/chihaya/stats"
+	"github.com/psaab/chihaya/tracker"
 )
 
 type keypairReloader struct {
@@ -109,7 +116,38 @@ type Server struct {
 	tracker  *tracker.Tracker
 	http     *graceful.Server
 	https    *graceful.Server
+	h2c      *graceful.Server
+	acmeMgr  *autocert.Manager
 	stopping bool
+
+	listener      net.Listener
+	inheritedFile *os.File
+	grpcServer    *grpc.Server
+}
+
+// SetInheritedListener configures the server to adopt an already-bound TCP
+// socket (handed down across a graceful restart or via socket activation)
+// instead of calling net.Listen.
+func (s *Server) SetInheritedListener(f *os.File) {
+	s.inheritedFile = f
+}
+
+// SetGRPCServer mounts g on this server's listener, so gRPC admin traffic
+// is matched and served alongside announce/scrape HTTP(S) traffic on the
+// same port instead of requiring a dedicated gRPC listener.
+func (s *Server) SetGRPCServer(g *grpc.Server) {
+	s.grpcServer = g
+}
+
+// ListenerFile returns a duplicated file descriptor for the server's
+// listening socket, suitable for passing to a child process across a
+// graceful restart.
+func (s *Server) ListenerFile() (*os.File, error) {
+	tl, ok := s.listener.(*net.TCPListener)
+	if !ok {
+		return nil, errors.New("listener does not support file handoff")
+	}
+	return tl.File()
 }
 
 // makeHandler wraps our ResponseHandlers while timing requests, collecting,
@@ -208,16 +246,77 @@ func (s *Server) Serve() {
 		glog.V(0).Info("Limiting connections to ", s.config.HTTPConfig.ListenLimit)
 	}
 
-	l, err := net.Listen("tcp", s.config.HTTPConfig.ListenAddr)
-	if err != nil {
-		panic(err)
+	var l net.Listener
+	var err error
+
+	if s.inheritedFile != nil {
+		l, err = net.FileListener(s.inheritedFile)
+		if err != nil {
+			panic(err)
+		}
+		s.inheritedFile.Close()
+	} else {
+		l, err = net.Listen("tcp", s.config.HTTPConfig.ListenAddr)
+		if err != nil {
+			panic(err)
+		}
 	}
+	s.listener = l
 
 	// Create a cmux.
 	mux := cmux.New(l)
+
+	// Match gRPC ahead of everything else so it doesn't get swallowed by
+	// the more general HTTP/2 matcher below.
+	var grpcListener net.Listener
+	if s.grpcServer != nil {
+		grpcListener = mux.Match(cmux.HTTP2HeaderField("content-type", "application/grpc"))
+	}
+
+	// Match h2c (HTTP/2 over cleartext) ahead of HTTP/1.1 so clients behind
+	// a reverse proxy that downgrades to h2c are still routed correctly.
+	var h2cListener net.Listener
+	if !s.config.HTTPConfig.DisableHTTP2 {
+		h2cListener = mux.Match(cmux.HTTP2())
+	}
 	httpListener := mux.Match(cmux.HTTP1Fast())
 
-	if s.config.HTTPConfig.TLSCertPath != "" && s.config.HTTPConfig.TLSKeyPath != "" {
+	if s.config.HTTPConfig.ACME != nil {
+		glog.V(0).Info("Starting HTTPS on ", s.config.HTTPConfig.ListenAddr, " via ACME")
+
+		acmeCfg := s.config.HTTPConfig.ACME
+		s.acmeMgr = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(acmeCfg.Hosts...),
+			Cache:      autocert.DirCache(acmeCfg.CacheDir),
+			Email:      acmeCfg.Email,
+		}
+		if acmeCfg.DirectoryURL != "" {
+			s.acmeMgr.Client = &acme.Client{DirectoryURL: acmeCfg.DirectoryURL}
+		}
+
+		tlsCfg := s.acmeMgr.TLSConfig()
+		if s.config.HTTPConfig.DisableHTTP2 {
+			// autocert always advertises h2 via ALPN; strip it back out so
+			// DisableHTTP2 behaves the same for ACME-provisioned certs as
+			// it does for the manual TLSCertPath/TLSKeyPath path below.
+			tlsCfg.NextProtos = withoutH2(tlsCfg.NextProtos)
+		}
+
+		s.https = newGraceful(s, true)
+		s.https.SetKeepAlivesEnabled(false)
+		s.https.ShutdownInitiated = func() { s.stopping = true }
+
+		// Create TLS listener.
+		httpsListener := tls.NewListener(mux.Match(cmux.Any()), tlsCfg)
+
+		go func() {
+			if err := s.https.Serve(httpsListener); err != nil && err != cmux.ErrListenerClosed {
+				panic(err)
+			}
+			glog.Info("HTTPS server shut down cleanly")
+		}()
+	} else if s.config.HTTPConfig.TLSCertPath != "" && s.config.HTTPConfig.TLSKeyPath != "" {
 		glog.V(0).Info("Starting HTTPS on ", s.config.HTTPConfig.ListenAddr)
 
 		kpr, err := NewKeypairReloader(s.config.HTTPConfig.TLSCertPath, s.config.HTTPConfig.TLSKeyPath)
@@ -228,6 +327,9 @@ func (s *Server) Serve() {
 		tlsCfg := &tls.Config{
 			GetCertificate: kpr.GetCertificateFunc(),
 		}
+		if !s.config.HTTPConfig.DisableHTTP2 {
+			tlsCfg.NextProtos = []string{"h2", "http/1.1"}
+		}
 
 		s.https = newGraceful(s, true)
 		s.https.SetKeepAlivesEnabled(false)
@@ -248,6 +350,14 @@ func (s *Server) Serve() {
 	s.http.SetKeepAlivesEnabled(false)
 	s.http.ShutdownInitiated = func() { s.stopping = true }
 
+	httpHandler := s.http.Handler
+	if s.acmeMgr != nil {
+		// Answer HTTP-01 challenges on the plaintext mux so operators don't
+		// need to open a separate port for cert issuance/renewal.
+		httpHandler = s.acmeMgr.HTTPHandler(httpHandler)
+		s.http.Handler = httpHandler
+	}
+
 	go func() {
 		if err := s.http.Serve(httpListener); err != nil && err != cmux.ErrListenerClosed {
 			panic(err)
@@ -255,6 +365,38 @@ func (s *Server) Serve() {
 		glog.Info("HTTP server shut down cleanly")
 	}()
 
+	if h2cListener != nil {
+		// http2.ConfigureServer (in newGraceful) only wires up ALPN-negotiated
+		// h2-over-TLS; serving h2c (cleartext HTTP/2) requires explicitly
+		// wrapping the handler so the "PRI * HTTP/2.0" client preface isn't
+		// misparsed as a bogus HTTP/1.1 request. Wrap it in a graceful.Server,
+		// same as s.http and s.https, so Stop() drains in-flight h2c
+		// announces instead of cutting them.
+		s.h2c = &graceful.Server{
+			Timeout:          s.config.HTTPConfig.RequestTimeout.Duration,
+			ConnState:        s.connState,
+			NoSignalHandling: true,
+			Server:           &http.Server{Handler: h2c.NewHandler(httpHandler, &http2.Server{})},
+		}
+		s.h2c.ShutdownInitiated = func() { s.stopping = true }
+
+		go func() {
+			if err := s.h2c.Serve(h2cListener); err != nil && err != cmux.ErrListenerClosed {
+				panic(err)
+			}
+			glog.Info("h2c server shut down cleanly")
+		}()
+	}
+
+	if grpcListener != nil {
+		go func() {
+			if err := s.grpcServer.Serve(grpcListener); err != nil && err != cmux.ErrListenerClosed {
+				panic(err)
+			}
+			glog.Info("gRPC admin API shut down cleanly")
+		}()
+	}
+
 	if err := mux.Serve(); !strings.Contains(err.Error(), "use of closed network connection") {
 		panic(err)
 	}
@@ -267,6 +409,12 @@ func (s *Server) Stop() {
 		if s.https != nil {
 			s.https.Stop(s.https.Timeout)
 		}
+		if s.h2c != nil {
+			s.h2c.Stop(s.h2c.Timeout)
+		}
+		if s.grpcServer != nil {
+			s.grpcServer.GracefulStop()
+		}
 	}
 }
 
@@ -275,19 +423,39 @@ func newGraceful(s *Server, ssl bool) *graceful.Server {
 	if ssl {
 		connState = s.connStateSSL
 	}
+
+	srv := &http.Server{
+		Addr:         s.config.HTTPConfig.ListenAddr,
+		Handler:      newRouter(s, ssl),
+		ReadTimeout:  s.config.HTTPConfig.ReadTimeout.Duration,
+		WriteTimeout: s.config.HTTPConfig.WriteTimeout.Duration,
+	}
+
+	if !s.config.HTTPConfig.DisableHTTP2 {
+		if err := http2.ConfigureServer(srv, nil); err != nil {
+			glog.Errorf("Failed to configure HTTP/2, falling back to HTTP/1.1: %s", err)
+		}
+	}
+
 	return &graceful.Server{
 		Timeout:     s.config.HTTPConfig.RequestTimeout.Duration,
 		ConnState:   connState,
 		ListenLimit: s.config.HTTPConfig.ListenLimit,
 
 		NoSignalHandling: true,
-		Server: &http.Server{
-			Addr:         s.config.HTTPConfig.ListenAddr,
-			Handler:      newRouter(s, ssl),
-			ReadTimeout:  s.config.HTTPConfig.ReadTimeout.Duration,
-			WriteTimeout: s.config.HTTPConfig.WriteTimeout.Duration,
-		},
+		Server:           srv,
+	}
+}
+
+// withoutH2 returns protos with any "h2" entry removed.
+func withoutH2(protos []string) []string {
+	out := protos[:0:0]
+	for _, p := range protos {
+		if p != "h2" {
+			out = append(out, p)
+		}
 	}
+	return out
 }
 
 // NewServer returns a new HTTP server for a given configuration and tracker.
@@ -0,0 +1,108 @@
+// Copyright 2015 The Chihaya Authors. All rights reserved.
+// Use of this source code is governed by the BSD 2-Clause license,
+// which can be found in the LICENSE file.
+
+package udp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// ivSize is the size, in bytes, of the HMAC key used to mint connection
+// IDs, as per BEP 15.
+const ivSize = 8
+
+// ConnectionIDGenerator mints and validates the 8-byte connection IDs
+// BitTorrent UDP trackers hand out in response to a connect request, per
+// BEP 15. IDs are derived from the requesting IP and the current IV via
+// HMAC, so they can be validated statelessly without storing one per
+// client.
+type ConnectionIDGenerator struct {
+	mu sync.RWMutex
+	iv []byte
+}
+
+// NewConnectionIDGenerator returns a new generator seeded with a random
+// IV.
+func NewConnectionIDGenerator() (*ConnectionIDGenerator, error) {
+	iv := make([]byte, ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	return &ConnectionIDGenerator{iv: iv}, nil
+}
+
+// NewConnectionIDGeneratorFromIV returns a new generator seeded with an
+// existing IV, so connection IDs minted by a previous process (e.g. before
+// a graceful restart) remain valid.
+func NewConnectionIDGeneratorFromIV(iv []byte) (*ConnectionIDGenerator, error) {
+	if len(iv) != ivSize {
+		return nil, errors.New("connection ID IV must be 8 bytes")
+	}
+
+	cp := make([]byte, ivSize)
+	copy(cp, iv)
+
+	return &ConnectionIDGenerator{iv: cp}, nil
+}
+
+// NewIV rotates the generator's IV. Connection IDs minted under the
+// previous IV stop validating once this is called, which is why Server
+// only calls it on an hourly timer rather than per-request.
+func (g *ConnectionIDGenerator) NewIV() error {
+	iv := make([]byte, ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.iv = iv
+	g.mu.Unlock()
+
+	return nil
+}
+
+// IV returns the generator's current HMAC key, so it can be handed to a
+// replacement process across a graceful restart.
+func (g *ConnectionIDGenerator) IV() []byte {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	cp := make([]byte, len(g.iv))
+	copy(cp, g.iv)
+	return cp
+}
+
+// Generate returns a new connection ID for the given address. It validates
+// for the rest of the current 300-second window and all of the next one,
+// and stops validating earlier if NewIV is called in the meantime.
+func (g *ConnectionIDGenerator) Generate(addr *net.UDPAddr, now time.Time) []byte {
+	return g.sign(addr, now.Unix()/300)
+}
+
+// Validate reports whether id was minted by Generate for addr under the
+// current or immediately preceding time window.
+func (g *ConnectionIDGenerator) Validate(id []byte, addr *net.UDPAddr, now time.Time) bool {
+	window := now.Unix() / 300
+	return hmac.Equal(id, g.sign(addr, window)) || hmac.Equal(id, g.sign(addr, window-1))
+}
+
+func (g *ConnectionIDGenerator) sign(addr *net.UDPAddr, window int64) []byte {
+	g.mu.RLock()
+	iv := g.iv
+	g.mu.RUnlock()
+
+	mac := hmac.New(sha1.New, iv)
+	mac.Write(addr.IP)
+	binary.Write(mac, binary.BigEndian, window)
+
+	return mac.Sum(nil)[:8]
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 The Chihaya Authors. All rights reserved.
+// Use of this source code is governed by the BSD 2-Clause license,
+// which can be found in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnectionIDGeneratorRoundTrip(t *testing.T) {
+	g, err := NewConnectionIDGenerator()
+	if err != nil {
+		t.Fatalf("NewConnectionIDGenerator: %s", err)
+	}
+
+	addr := &net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 6881}
+	now := time.Unix(1000000, 0)
+
+	id := g.Generate(addr, now)
+	if !g.Validate(id, addr, now) {
+		t.Error("Validate rejected an ID minted in the current window")
+	}
+}
+
+func TestConnectionIDGeneratorValidatesPreviousWindow(t *testing.T) {
+	g, err := NewConnectionIDGenerator()
+	if err != nil {
+		t.Fatalf("NewConnectionIDGenerator: %s", err)
+	}
+
+	addr := &net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 6881}
+	now := time.Unix(1000000, 0)
+
+	id := g.Generate(addr, now)
+	later := now.Add(300 * time.Second)
+	if !g.Validate(id, addr, later) {
+		t.Error("Validate rejected an ID minted in the immediately preceding window")
+	}
+
+	tooLate := now.Add(600 * time.Second)
+	if g.Validate(id, addr, tooLate) {
+		t.Error("Validate accepted an ID minted two windows ago")
+	}
+}
+
+func TestConnectionIDGeneratorRejectsWrongAddr(t *testing.T) {
+	g, err := NewConnectionIDGenerator()
+	if err != nil {
+		t.Fatalf("NewConnectionIDGenerator: %s", err)
+	}
+
+	now := time.Unix(1000000, 0)
+	id := g.Generate(&net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 6881}, now)
+
+	other := &net.UDPAddr{IP: net.ParseIP("203.0.113.2"), Port: 6881}
+	if g.Validate(id, other, now) {
+		t.Error("Validate accepted an ID minted for a different address")
+	}
+}
+
+func TestConnectionIDGeneratorSurvivesIVHandoff(t *testing.T) {
+	g1, err := NewConnectionIDGenerator()
+	if err != nil {
+		t.Fatalf("NewConnectionIDGenerator: %s", err)
+	}
+
+	addr := &net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 6881}
+	now := time.Unix(1000000, 0)
+	id := g1.Generate(addr, now)
+
+	g2, err := NewConnectionIDGeneratorFromIV(g1.IV())
+	if err != nil {
+		t.Fatalf("NewConnectionIDGeneratorFromIV: %s", err)
+	}
+
+	if !g2.Validate(id, addr, now) {
+		t.Error("ID minted before the handoff did not validate against the IV-seeded generator")
+	}
+}
+
+func TestNewConnectionIDGeneratorFromIVRejectsWrongSize(t *testing.T) {
+	if _, err := NewConnectionIDGeneratorFromIV([]byte{1, 2, 3}); err == nil {
+		t.Error("expected an error for an IV that isn't 8 bytes")
+	}
+}
+
+func TestConnectionIDGeneratorNewIVInvalidatesOldIDs(t *testing.T) {
+	g, err := NewConnectionIDGenerator()
+	if err != nil {
+		t.Fatalf("NewConnectionIDGenerator: %s", err)
+	}
+
+	addr := &net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 6881}
+	now := time.Unix(1000000, 0)
+	id := g.Generate(addr, now)
+
+	if err := g.NewIV(); err != nil {
+		t.Fatalf("NewIV: %s", err)
+	}
+
+	if g.Validate(id, addr, now) {
+		t.Error("Validate accepted an ID minted under a rotated-out IV")
+	}
+}
@@ -7,8 +7,10 @@
 package udp
 
 import (
+	"encoding/hex"
 	"errors"
 	"net"
+	"os"
 	"sync"
 	"time"
 
@@ -19,6 +21,11 @@ import (
 	"github.com/psaab/chihaya/tracker"
 )
 
+// ConnIDIVEnv names the environment variable used to hand the connection ID
+// HMAC key from a parent process to its replacement across a graceful
+// restart, so connection IDs minted before the restart remain valid.
+const ConnIDIVEnv = "CHIHAYA_UDP_IV"
+
 // Server represents a UDP torrent tracker.
 type Server struct {
 	config    *config.Config
@@ -26,26 +33,86 @@ type Server struct {
 	sock      *net.UDPConn
 	connIDGen *ConnectionIDGenerator
 
+	inheritedFile *os.File
+
 	closing chan struct{}
 	booting chan struct{}
 	wg      sync.WaitGroup
 }
 
+// SetInheritedListener configures the server to adopt an already-bound UDP
+// socket (handed down across a graceful restart or via socket activation)
+// instead of calling net.ListenUDP.
+func (s *Server) SetInheritedListener(f *os.File) {
+	s.inheritedFile = f
+}
+
+// ListenerFile returns a duplicated file descriptor for the server's UDP
+// socket, suitable for passing to a child process across a graceful
+// restart.
+func (s *Server) ListenerFile() (*os.File, error) {
+	if s.sock == nil {
+		return nil, errors.New("server not yet booted")
+	}
+	return s.sock.File()
+}
+
+// ConnectionIDIV returns the current HMAC key used to mint connection IDs,
+// so it can be handed to a replacement process across a graceful restart.
+func (s *Server) ConnectionIDIV() []byte {
+	return s.connIDGen.IV()
+}
+
+// inheritedConnIDIV reads a hex-encoded connection ID HMAC key handed down
+// by a parent process, if any.
+func inheritedConnIDIV() ([]byte, bool) {
+	hexIV := os.Getenv(ConnIDIVEnv)
+	if hexIV == "" {
+		return nil, false
+	}
+
+	iv, err := hex.DecodeString(hexIV)
+	if err != nil {
+		glog.Errorf("Invalid %s: %s", ConnIDIVEnv, err)
+		return nil, false
+	}
+
+	return iv, true
+}
+
 func (s *Server) serve() error {
 	if s.sock != nil {
 		return errors.New("server already booted")
 	}
 
-	udpAddr, err := net.ResolveUDPAddr("udp", s.config.UDPConfig.ListenAddr)
-	if err != nil {
-		close(s.booting)
-		return err
-	}
+	var sock *net.UDPConn
 
-	sock, err := net.ListenUDP("udp", udpAddr)
-	if err != nil {
-		close(s.booting)
-		return err
+	if s.inheritedFile != nil {
+		conn, err := net.FilePacketConn(s.inheritedFile)
+		if err != nil {
+			close(s.booting)
+			return err
+		}
+		s.inheritedFile.Close()
+
+		var ok bool
+		sock, ok = conn.(*net.UDPConn)
+		if !ok {
+			close(s.booting)
+			return errors.New("inherited file is not a UDP socket")
+		}
+	} else {
+		udpAddr, err := net.ResolveUDPAddr("udp", s.config.UDPConfig.ListenAddr)
+		if err != nil {
+			close(s.booting)
+			return err
+		}
+
+		sock, err = net.ListenUDP("udp", udpAddr)
+		if err != nil {
+			close(s.booting)
+			return err
+		}
 	}
 	defer sock.Close()
 
@@ -133,7 +200,14 @@ func (s *Server) Stop() {
 
 // NewServer returns a new UDP server for a given configuration and tracker.
 func NewServer(cfg *config.Config, tkr *tracker.Tracker) *Server {
-	gen, err := NewConnectionIDGenerator()
+	var gen *ConnectionIDGenerator
+	var err error
+
+	if iv, ok := inheritedConnIDIV(); ok {
+		gen, err = NewConnectionIDGeneratorFromIV(iv)
+	} else {
+		gen, err = NewConnectionIDGenerator()
+	}
 	if err != nil {
 		panic(err)
 	}